@@ -0,0 +1,178 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analysis adapts gosec's rules to the go/analysis framework, so
+// gosec can be embedded in go vet, golangci-lint and nogo pipelines without
+// invoking gosec.Analyzer.Process directly.
+package analysis
+
+import (
+	"go/ast"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/DongwooGim/gosec"
+)
+
+// Analyzers wraps every rule in ruleDefinitions as its own
+// *analysis.Analyzer, using conf to build each rule exactly as
+// gosec.Analyzer.LoadRules would. Callers can then schedule and cache
+// gosec's findings with the standard analysis framework instead of
+// treating gosec as an opaque binary.
+func Analyzers(conf gosec.Config, ruleDefinitions map[string]gosec.RuleBuilder) []*analysis.Analyzer {
+	analyzers := make([]*analysis.Analyzer, 0, len(ruleDefinitions))
+	for id, def := range ruleDefinitions {
+		analyzers = append(analyzers, newAnalyzer(id, def, conf))
+	}
+	return analyzers
+}
+
+// newAnalyzer builds the *analysis.Analyzer wrapping a single gosec rule.
+func newAnalyzer(id string, def gosec.RuleBuilder, conf gosec.Config) *analysis.Analyzer {
+	rule, nodeFilter := def(id, conf)
+	ignoreNosec, _ := conf.IsGlobalEnabled(gosec.Nosec)
+	groups := append(conf.Groups(), gosec.DefaultGroups()...)
+
+	return &analysis.Analyzer{
+		Name:     id,
+		Doc:      "gosec rule " + id + ", run through go/analysis",
+		Requires: []*analysis.Analyzer{inspect.Analyzer},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			runRule(rule, nodeFilter, ignoreNosec, conf, groups, pass)
+			return nil, nil
+		},
+	}
+}
+
+// runRule drives a single gosec.Rule over pass, reconstructing the minimal
+// gosec.Context it needs from the pass (Fset, Pkg, TypesInfo, Files) and
+// preserving the #nosec handling, per-branch ignores stack, declarative
+// FileIgnore/LineIgnore suppressions and per-group rule disabling exactly as
+// Job.Visit and Analyzer.Process do for a standalone gosec.Analyzer.Process
+// run. Without this, the same rule run through this adapter would report
+// issues that a direct Process call would silently suppress.
+func runRule(rule gosec.Rule, nodeFilter []ast.Node, ignoreNosec bool, conf gosec.Config, groups []gosec.Group, pass *analysis.Pass) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	comments := make(map[*ast.File]ast.CommentMap, len(pass.Files))
+	imports := make(map[*ast.File]*gosec.ImportTracker, len(pass.Files))
+	fileGroups := make(map[*ast.File]string, len(pass.Files))
+	declaredIgnores := gosec.NewLineIgnores(conf.Ignores())
+	for _, file := range pass.Files {
+		comments[file] = ast.NewCommentMap(pass.Fset, file, file.Comments)
+		tracker := gosec.NewImportTracker()
+		tracker.TrackPackages(pass.Pkg.Imports()...)
+		imports[file] = tracker
+
+		filePath := pass.Fset.File(file.Pos()).Name()
+		fileGroups[file] = gosec.GroupFor(groups, filePath, file)
+		if fileIgnore, ok := gosec.NewFileIgnore(file, filePath); ok {
+			declaredIgnores = append(declaredIgnores, fileIgnore)
+		}
+	}
+
+	ignores := make(map[*ast.File][]map[string]bool, len(pass.Files))
+
+	insp.WithStack(nil, func(n ast.Node, push bool, stack []ast.Node) bool {
+		file := stack[0].(*ast.File)
+
+		if !push {
+			if stack := ignores[file]; len(stack) > 0 {
+				ignores[file] = stack[1:]
+			}
+			return true
+		}
+
+		var ignoredRules []string
+		if !ignoreNosec {
+			ids, ignoreAll, _ := gosec.ParseNosecComment(comments[file], n)
+			if ignoreAll {
+				return false
+			}
+			ignoredRules = ids
+		}
+
+		union := make(map[string]bool, len(ignoredRules))
+		if stack := ignores[file]; len(stack) > 0 {
+			for k, v := range stack[0] {
+				union[k] = v
+			}
+		}
+		for _, id := range ignoredRules {
+			union[id] = true
+		}
+		ignores[file] = append([]map[string]bool{union}, ignores[file]...)
+
+		imports[file].TrackImport(n)
+
+		if union[rule.ID()] || !matchesFilter(nodeFilter, n) {
+			return true
+		}
+		if gosec.GroupRuleDisabled(conf, rule.ID(), fileGroups[file]) {
+			return true
+		}
+
+		ctx := &gosec.Context{
+			FileSet:  pass.Fset,
+			Comments: comments[file],
+			Info:     pass.TypesInfo,
+			Pkg:      pass.Pkg,
+			PkgFiles: pass.Files,
+			Root:     file,
+			Imports:  imports[file],
+		}
+
+		issue, err := rule.Match(n, ctx)
+		if err != nil {
+			pass.Reportf(n.Pos(), "gosec: rule error: %s", err)
+			return true
+		}
+		if issue != nil && !suppressed(declaredIgnores, issue) {
+			pass.Report(analysis.Diagnostic{Pos: n.Pos(), Message: issue.What})
+		}
+		return true
+	})
+}
+
+// suppressed reports whether issue is covered by one of the declarative
+// FileIgnore/LineIgnore suppressions gathered for this pass, marking the
+// matching Ignore as having fired. This mirrors Analyzer.suppressed; unlike
+// that method, no mutex is needed here since a Pass is only ever driven by
+// one goroutine.
+func suppressed(ignores []gosec.Ignore, issue *gosec.Issue) bool {
+	found := false
+	for _, ignore := range ignores {
+		if ignore.Match(issue) {
+			found = true
+		}
+	}
+	return found
+}
+
+// matchesFilter reports whether n's dynamic type is one of nodeFilter,
+// mirroring how gosec.RuleSet.RegisteredFor selects rules for a node.
+func matchesFilter(nodeFilter []ast.Node, n ast.Node) bool {
+	if len(nodeFilter) == 0 {
+		return true
+	}
+	for _, want := range nodeFilter {
+		if reflect.TypeOf(want) == reflect.TypeOf(n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strconv"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/DongwooGim/gosec"
+)
+
+func TestMatchesFilter(t *testing.T) {
+	ident := &ast.Ident{}
+	call := &ast.CallExpr{}
+
+	if !matchesFilter(nil, ident) {
+		t.Error("an empty filter should match every node")
+	}
+
+	filter := []ast.Node{(*ast.CallExpr)(nil)}
+	if !matchesFilter(filter, call) {
+		t.Error("expected a *ast.CallExpr to match a *ast.CallExpr filter")
+	}
+	if matchesFilter(filter, ident) {
+		t.Error("a *ast.Ident must not match a *ast.CallExpr-only filter")
+	}
+}
+
+func TestSuppressedByLineIgnore(t *testing.T) {
+	ignore := &gosec.LineIgnore{File: "a.go", StartLine: 1, EndLine: 10, IDs: []string{"G101"}}
+	issue := &gosec.Issue{File: "a.go", Line: "5", Col: "1", RuleID: "G101"}
+
+	if !suppressed([]gosec.Ignore{ignore}, issue) {
+		t.Error("expected the issue to be suppressed by the matching LineIgnore")
+	}
+	if !ignore.Matched() {
+		t.Error("expected the LineIgnore to be marked matched after a hit")
+	}
+}
+
+func TestSuppressedNoMatch(t *testing.T) {
+	ignore := &gosec.LineIgnore{File: "a.go", StartLine: 1, EndLine: 10, IDs: []string{"G101"}}
+	issue := &gosec.Issue{File: "b.go", Line: "5", Col: "1", RuleID: "G101"}
+
+	if suppressed([]gosec.Ignore{ignore}, issue) {
+		t.Error("an issue in a different file must not be suppressed")
+	}
+	if ignore.Matched() {
+		t.Error("an Ignore that never matched must not report Matched()")
+	}
+}
+
+// countingRule is a minimal gosec.Rule that reports one issue per
+// *ast.CallExpr it sees, so runRule's nosec/ignores-stack handling can be
+// exercised without a real security rule.
+type countingRule struct {
+	matches []*ast.CallExpr
+}
+
+func (r *countingRule) ID() string { return "G999" }
+
+func (r *countingRule) Match(n ast.Node, ctx *gosec.Context) (*gosec.Issue, error) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return nil, nil
+	}
+	r.matches = append(r.matches, call)
+	pos := ctx.FileSet.Position(n.Pos())
+	return &gosec.Issue{
+		RuleID: r.ID(),
+		What:   "test issue",
+		File:   pos.Filename,
+		Line:   strconv.Itoa(pos.Line),
+		Col:    strconv.Itoa(pos.Column),
+	}, nil
+}
+
+const runRuleTestSrc = `package p
+
+func safe() {
+	ignored() //#nosec G999
+	reported()
+}
+func ignored() {}
+func reported() {}
+`
+
+// buildTestPass type-checks runRuleTestSrc and assembles the minimal
+// *analysis.Pass that runRule needs: Fset, Files, Pkg, TypesInfo and an
+// inspect.Analyzer result.
+func buildTestPass(t *testing.T) (*analysis.Pass, []string) {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", runRuleTestSrc, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatalf("type-check error: %v", err)
+	}
+
+	insp := inspector.New([]*ast.File{file})
+
+	var reported []string
+	pass := &analysis.Pass{
+		Fset:      fset,
+		Files:     []*ast.File{file},
+		Pkg:       pkg,
+		TypesInfo: info,
+		ResultOf:  map[*analysis.Analyzer]interface{}{inspect.Analyzer: insp},
+		Report: func(d analysis.Diagnostic) {
+			reported = append(reported, d.Message)
+		},
+	}
+	return pass, reported
+}
+
+func TestRunRuleHonorsNosec(t *testing.T) {
+	pass, _ := buildTestPass(t)
+	rule := &countingRule{}
+
+	var reported []string
+	pass.Report = func(d analysis.Diagnostic) {
+		reported = append(reported, d.Message)
+	}
+
+	runRule(rule, nil, false, gosec.NewConfig(), nil, pass)
+
+	if len(rule.matches) != 1 {
+		t.Fatalf("expected the rule to be offered only the non-#nosec'd call site, got %d", len(rule.matches))
+	}
+	if len(reported) != 1 {
+		t.Fatalf("expected exactly one reported diagnostic (the #nosec'd call must be skipped), got %d: %v", len(reported), reported)
+	}
+}
+
+func TestRunRuleIgnoreNosecGlobalOption(t *testing.T) {
+	pass, _ := buildTestPass(t)
+	rule := &countingRule{}
+
+	var reported []string
+	pass.Report = func(d analysis.Diagnostic) {
+		reported = append(reported, d.Message)
+	}
+
+	runRule(rule, nil, true, gosec.NewConfig(), nil, pass)
+
+	if len(reported) != 2 {
+		t.Fatalf("with ignoreNosec set, #nosec comments must be ignored; got %d diagnostics, want 2", len(reported))
+	}
+}
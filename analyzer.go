@@ -23,13 +23,15 @@ import (
 	"log"
 	"os"
 	"path"
-	"reflect"
-	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
+	"sync"
 
 	"strings"
 
 	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
 )
 
 // The Context is populated with data parsed from the source code as it is scanned.
@@ -44,7 +46,8 @@ type Context struct {
 	Root     *ast.File
 	Config   Config
 	Imports  *ImportTracker
-	Ignores  []map[string]bool
+	SSAProg  *ssa.Program // nil unless the BuildSSA config option is set
+	SSAPkg   *ssa.Package // nil unless the BuildSSA config option is set
 }
 
 // Metrics used when reporting information about a scanning run.
@@ -60,12 +63,17 @@ type Metrics struct {
 type Analyzer struct {
 	ignoreNosec bool
 	ruleset     RuleSet
-	context     *Context
 	config      Config
 	logger      *log.Logger
 	issues      []*Issue
 	stats       *Metrics
 	errors      map[string][]Error // keys are file paths; values are the golang errors in those files
+	ignores     []Ignore           // declarative suppressions gathered from file directives and config
+	ignoresMu   sync.Mutex         // guards ignores, which Jobs match against concurrently
+	mergeMu     sync.Mutex         // guards issues, stats and errors while Jobs are merged in
+	concurrency int                // number of Jobs run in parallel, see SetConcurrency
+	ssaEnabled  bool               // set from the BuildSSA config option
+	ssaRules    []SSARule          // rules that also implement SSARule, gathered in LoadRules
 }
 
 // NewAnalyzer builds a new analyzer.
@@ -74,27 +82,46 @@ func NewAnalyzer(conf Config, logger *log.Logger) *Analyzer {
 	if enabled, err := conf.IsGlobalEnabled(Nosec); err == nil {
 		ignoreNoSec = enabled
 	}
+	ssaEnabled := false
+	if enabled, err := conf.IsGlobalEnabled(BuildSSA); err == nil {
+		ssaEnabled = enabled
+	}
 	if logger == nil {
 		logger = log.New(os.Stderr, "[gosec]", log.LstdFlags)
 	}
 	return &Analyzer{
 		ignoreNosec: ignoreNoSec,
 		ruleset:     make(RuleSet),
-		context:     &Context{},
 		config:      conf,
 		logger:      logger,
 		issues:      make([]*Issue, 0, 16),
 		stats:       &Metrics{},
 		errors:      make(map[string][]Error),
+		ignores:     NewLineIgnores(conf.Ignores()),
+		concurrency: runtime.GOMAXPROCS(0),
+		ssaEnabled:  ssaEnabled,
 	}
 }
 
+// SetConcurrency sets the number of Jobs (one per file) that Process will
+// run in parallel. The default, set by NewAnalyzer, is GOMAXPROCS. Passing
+// n <= 0 is treated as 1, i.e. the historical serial behaviour.
+func (gosec *Analyzer) SetConcurrency(n int) {
+	if n <= 0 {
+		n = 1
+	}
+	gosec.concurrency = n
+}
+
 // LoadRules instantiates all the rules to be used when analyzing source
 // packages
 func (gosec *Analyzer) LoadRules(ruleDefinitions map[string]RuleBuilder) {
 	for id, def := range ruleDefinitions {
 		r, nodes := def(id, gosec.config)
 		gosec.ruleset.Register(r, nodes...)
+		if ssaRule, ok := r.(SSARule); ok {
+			gosec.ssaRules = append(gosec.ssaRules, ssaRule)
+		}
 	}
 }
 
@@ -164,107 +191,140 @@ func (gosec *Analyzer) Process(buildTags []string, packagePaths ...string) error
 
 	sortErrors(gosec.errors) // sorts errors by line and column in the file
 
+	var ssaProg *ssa.Program
+	var ssaPkgsByType map[*types.Package]*ssa.Package
+	if gosec.ssaEnabled {
+		ssaProg, ssaPkgsByType = buildSSA(pkgs)
+	}
+
+	groups := append(gosec.config.Groups(), DefaultGroups()...)
+
+	jobs := make([]*Job, 0, len(pkgs))
 	for _, pkg := range pkgs {
 		gosec.logger.Println("Checking package:", pkg.Name)
 		for _, file := range pkg.Syntax {
 			gosec.logger.Println("Checking file:", pkg.Fset.File(file.Pos()).Name())
-			gosec.context.FileSet = pkg.Fset
-			gosec.context.Config = gosec.config
-			gosec.context.Comments = ast.NewCommentMap(gosec.context.FileSet, file, file.Comments)
-			gosec.context.Root = file
-			gosec.context.Info = pkg.TypesInfo
-			gosec.context.Pkg = pkg.Types
-			gosec.context.PkgFiles = pkg.Syntax
-			gosec.context.Imports = NewImportTracker()
-			gosec.context.Imports.TrackPackages(gosec.context.Pkg.Imports()...)
-			ast.Walk(gosec, file)
-			gosec.stats.NumFiles++
-			gosec.stats.NumLines += pkg.Fset.File(file.Pos()).LineCount()
-		}
-	}
+			ctx := &Context{
+				FileSet:  pkg.Fset,
+				Config:   gosec.config,
+				Comments: ast.NewCommentMap(pkg.Fset, file, file.Comments),
+				Root:     file,
+				Info:     pkg.TypesInfo,
+				Pkg:      pkg.Types,
+				PkgFiles: pkg.Syntax,
+				Imports:  NewImportTracker(),
+				SSAProg:  ssaProg,
+				SSAPkg:   ssaPkgsByType[pkg.Types],
+			}
+			ctx.Imports.TrackPackages(ctx.Pkg.Imports()...)
 
-	return nil
-}
+			filePath := pkg.Fset.File(file.Pos()).Name()
+			if fileIgnore, ok := NewFileIgnore(file, filePath); ok {
+				gosec.ignores = append(gosec.ignores, fileIgnore)
+			}
 
-// ignore a node (and sub-tree) if it is tagged with a "#nosec" comment
-func (gosec *Analyzer) ignore(n ast.Node) ([]string, bool) {
-	if groups, ok := gosec.context.Comments[n]; ok && !gosec.ignoreNosec {
-		for _, group := range groups {
-			if strings.Contains(group.Text(), "#nosec") {
-				gosec.stats.NumNosec++
+			job := NewJob(gosec, ctx)
+			job.group = GroupFor(groups, filePath, file)
+			jobs = append(jobs, job)
+		}
+	}
 
-				// Pull out the specific rules that are listed to be ignored.
-				re := regexp.MustCompile("(G\\d{3})")
-				matches := re.FindAllStringSubmatch(group.Text(), -1)
+	gosec.runJobs(jobs)
 
-				// If no specific rules were given, ignore everything.
-				if matches == nil || len(matches) == 0 {
-					return nil, true
-				}
+	if gosec.ssaEnabled {
+		gosec.runSSARules(pkgs, ssaProg, ssaPkgsByType)
+	}
 
-				// Find the rule IDs to ignore.
-				var ignores []string
-				for _, v := range matches {
-					ignores = append(ignores, v[1])
-				}
-				return ignores, false
+	for _, ignore := range gosec.ignores {
+		if !ignore.Matched() {
+			if issue := unusedIgnoreIssue(ignore); issue != nil {
+				gosec.issues = append(gosec.issues, issue)
 			}
 		}
 	}
-	return nil, false
-}
 
-// Visit runs the gosec visitor logic over an AST created by parsing go code.
-// Rule methods added with AddRule will be invoked as necessary.
-func (gosec *Analyzer) Visit(n ast.Node) ast.Visitor {
-	// If we've reached the end of this branch, pop off the ignores stack.
-	if n == nil {
-		if len(gosec.context.Ignores) > 0 {
-			gosec.context.Ignores = gosec.context.Ignores[1:]
-		}
-		return gosec
-	}
+	sortIssues(gosec.issues)
 
-	// Get any new rule exclusions.
-	ignoredRules, ignoreAll := gosec.ignore(n)
-	if ignoreAll {
-		return nil
-	}
+	return nil
+}
 
-	// Now create the union of exclusions.
-	ignores := make(map[string]bool, 0)
-	if len(gosec.context.Ignores) > 0 {
-		for k, v := range gosec.context.Ignores[0] {
-			ignores[k] = v
-		}
+// runJobs runs jobs through a worker pool sized by gosec.concurrency and
+// merges each Job's issues, stats and errors back into the Analyzer as it
+// completes. Merging is serialized with mergeMu so concurrent Jobs never
+// race on the Analyzer's shared slices and maps.
+func (gosec *Analyzer) runJobs(jobs []*Job) {
+	queue := make(chan *Job)
+	var wg sync.WaitGroup
+
+	for i := 0; i < gosec.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				job.Run()
+				gosec.merge(job)
+			}
+		}()
 	}
 
-	for _, v := range ignoredRules {
-		ignores[v] = true
+	for _, job := range jobs {
+		queue <- job
 	}
+	close(queue)
 
-	// Push the new set onto the stack.
-	gosec.context.Ignores = append([]map[string]bool{ignores}, gosec.context.Ignores...)
+	wg.Wait()
+}
 
-	// Track aliased and initialization imports
-	gosec.context.Imports.TrackImport(n)
+// merge folds a completed Job's results into the Analyzer's issues, stats
+// and errors.
+func (gosec *Analyzer) merge(job *Job) {
+	gosec.mergeMu.Lock()
+	defer gosec.mergeMu.Unlock()
+
+	gosec.issues = append(gosec.issues, job.issues...)
+	gosec.stats.NumFiles += job.stats.NumFiles
+	gosec.stats.NumLines += job.stats.NumLines
+	gosec.stats.NumNosec += job.stats.NumNosec
+	gosec.stats.NumFound += job.stats.NumFound
+	for filePath, errs := range job.errors {
+		gosec.errors[filePath] = append(gosec.errors[filePath], errs...)
+	}
+}
 
-	for _, rule := range gosec.ruleset.RegisteredFor(n) {
-		if _, ok := ignores[rule.ID()]; ok {
-			continue
+// sortIssues orders issues deterministically by file, line, column and rule
+// ID, so that concurrent scanning does not make output order depend on
+// goroutine scheduling.
+func sortIssues(issues []*Issue) {
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
 		}
-		issue, err := rule.Match(n, gosec.context)
-		if err != nil {
-			file, line := GetLocation(n, gosec.context)
-			file = path.Base(file)
-			gosec.logger.Printf("Rule error: %v => %s (%s:%d)\n", reflect.TypeOf(rule), err, file, line)
+		if al, bl := issueLine(a), issueLine(b); al != bl {
+			return al < bl
+		}
+		if ac, bc := issueCol(a), issueCol(b); ac != bc {
+			return ac < bc
 		}
-		if issue != nil {
-			gosec.issues = append(gosec.issues, issue)
-			gosec.stats.NumFound++
+		return a.RuleID < b.RuleID
+	})
+}
+
+// suppressed reports whether issue is covered by one of the declarative
+// suppressions gathered from file directives or config, marking the
+// matching Ignore as having fired. It is called concurrently by Jobs, so
+// access to gosec.ignores is serialized with ignoresMu.
+func (gosec *Analyzer) suppressed(issue *Issue) bool {
+	gosec.ignoresMu.Lock()
+	defer gosec.ignoresMu.Unlock()
+
+	suppressed := false
+	for _, ignore := range gosec.ignores {
+		if ignore.Match(issue) {
+			suppressed = true
 		}
 	}
-	return gosec
+	return suppressed
 }
 
 // Report returns the current issues discovered and the metrics about the scan
@@ -274,7 +334,7 @@ func (gosec *Analyzer) Report() ([]*Issue, *Metrics, map[string][]Error) {
 
 // Reset clears state such as context, issues and metrics from the configured analyzer
 func (gosec *Analyzer) Reset() {
-	gosec.context = &Context{}
 	gosec.issues = make([]*Issue, 0, 16)
 	gosec.stats = &Metrics{}
+	gosec.ignores = NewLineIgnores(gosec.config.Ignores())
 }
@@ -0,0 +1,77 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSortIssues(t *testing.T) {
+	issues := []*Issue{
+		{File: "b.go", Line: "1", Col: "1", RuleID: "G101"},
+		{File: "a.go", Line: "10", Col: "2", RuleID: "G101"},
+		{File: "a.go", Line: "9", Col: "20", RuleID: "G101"},
+		{File: "a.go", Line: "9", Col: "3", RuleID: "G101"},
+	}
+
+	sortIssues(issues)
+
+	want := []string{"a.go:9:3", "a.go:9:20", "a.go:10:2", "b.go:1:1"}
+	got := make([]string, len(issues))
+	for i, issue := range issues {
+		got[i] = issue.File + ":" + issue.Line + ":" + issue.Col
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortIssues order = %v, want %v", got, want)
+	}
+}
+
+// TestAnalyzerMergeIsConcurrencySafe merges many Jobs into a single Analyzer
+// concurrently; run with -race to catch a regression to the unguarded
+// shared state Process used before the Job worker pool.
+func TestAnalyzerMergeIsConcurrencySafe(t *testing.T) {
+	analyzer := &Analyzer{
+		issues: make([]*Issue, 0),
+		stats:  &Metrics{},
+		errors: make(map[string][]Error),
+	}
+
+	const jobCount = 50
+	var wg sync.WaitGroup
+	for i := 0; i < jobCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job := &Job{
+				issues: []*Issue{{RuleID: "G101"}},
+				stats:  Metrics{NumFiles: 1, NumFound: 1},
+			}
+			analyzer.merge(job)
+		}()
+	}
+	wg.Wait()
+
+	if len(analyzer.issues) != jobCount {
+		t.Errorf("got %d merged issues, want %d", len(analyzer.issues), jobCount)
+	}
+	if analyzer.stats.NumFiles != jobCount {
+		t.Errorf("got NumFiles=%d, want %d", analyzer.stats.NumFiles, jobCount)
+	}
+	if analyzer.stats.NumFound != jobCount {
+		t.Errorf("got NumFound=%d, want %d", analyzer.stats.NumFound, jobCount)
+	}
+}
@@ -0,0 +1,119 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import "encoding/json"
+
+// GlobalOption names a configuration switch that applies to every rule,
+// rather than to one rule's settings in isolation.
+type GlobalOption string
+
+const (
+	// Nosec turns off #nosec comment handling entirely; when enabled,
+	// every #nosec directive is itself ignored.
+	Nosec GlobalOption = "nosec"
+)
+
+// globalsKey is the Config entry holding the map of GlobalOption to bool.
+const globalsKey = "global"
+
+// ignoresKey is the Config entry holding a []LineIgnoreConfig.
+const ignoresKey = "ignores"
+
+// groupsKey is the Config entry holding a []Group.
+const groupsKey = "groups"
+
+// Config is used to provide configuration and customization to each of the
+// rules, plus the global options and declarative suppressions that apply to
+// a whole scan. It is typically populated by unmarshalling a JSON or YAML
+// document, both of which decode into the same map[string]interface{}
+// shape.
+type Config map[string]interface{}
+
+// NewConfig returns an empty Config, ready to be populated via Set/SetGlobal
+// or by unmarshalling a JSON/YAML document into it directly.
+func NewConfig() Config {
+	return make(Config)
+}
+
+// Get returns the rule-specific settings stored under id, or nil if none
+// were configured.
+func (c Config) Get(id string) (interface{}, error) {
+	return c[id], nil
+}
+
+// Set stores rule-specific settings under id.
+func (c Config) Set(id string, value interface{}) {
+	c[id] = value
+}
+
+// IsGlobalEnabled reports whether a global option, such as Nosec or
+// BuildSSA, is turned on.
+func (c Config) IsGlobalEnabled(option GlobalOption) (bool, error) {
+	globals, ok := c[globalsKey].(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	enabled, _ := globals[string(option)].(bool)
+	return enabled, nil
+}
+
+// SetGlobal turns a global option on or off.
+func (c Config) SetGlobal(option GlobalOption, enabled bool) {
+	globals, ok := c[globalsKey].(map[string]interface{})
+	if !ok {
+		globals = make(map[string]interface{})
+		c[globalsKey] = globals
+	}
+	globals[string(option)] = enabled
+}
+
+// Ignores returns the declarative LineIgnore entries configured under the
+// "ignores" key, decoded via a JSON round-trip so they work whether Config
+// was populated from JSON or YAML. It returns nil if none were configured
+// or the entries are malformed.
+func (c Config) Ignores() []LineIgnoreConfig {
+	var entries []LineIgnoreConfig
+	if !decodeConfigValue(c[ignoresKey], &entries) {
+		return nil
+	}
+	return entries
+}
+
+// Groups returns the user-declared Group entries configured under the
+// "groups" key, decoded the same way as Ignores. DefaultGroups are not
+// included here; callers append them separately so built-ins always run,
+// even for configs with no Groups section of their own.
+func (c Config) Groups() []Group {
+	var groups []Group
+	if !decodeConfigValue(c[groupsKey], &groups) {
+		return nil
+	}
+	return groups
+}
+
+// decodeConfigValue converts a generic value decoded from JSON/YAML (maps,
+// slices, scalars) into a concrete Go type by round-tripping it through
+// encoding/json. It reports whether decoding succeeded.
+func decodeConfigValue(raw interface{}, out interface{}) bool {
+	if raw == nil {
+		return false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, out) == nil
+}
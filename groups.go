@@ -0,0 +1,147 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+)
+
+// doNotEditPattern matches the magic comment convention (see
+// https://golang.org/s/generatedcode) that marks a file as generated.
+var doNotEditPattern = regexp.MustCompile(`DO NOT EDIT`)
+
+// Group classifies files into a coarse bucket - generated code, vendored
+// dependencies, test fixtures, internal packages, and so on - so that rules
+// can be enabled or disabled across a whole bucket instead of file by file.
+// This mirrors nogo's group system.
+type Group struct {
+	Name    string `json:"name"`
+	Regex   string `json:"regex"`
+	Default bool   `json:"default"`
+
+	// generated marks the built-in "DO NOT EDIT" classifier from
+	// DefaultGroups. It is unexported so a user-declared group - however
+	// it happens to be named - can never unmarshal into this behavior; it
+	// can only come from this package's own built-ins.
+	generated bool
+
+	re *regexp.Regexp
+}
+
+// compile lazily compiles Regex, caching the result on the Group.
+func (g *Group) compile() (*regexp.Regexp, error) {
+	if g.re != nil || g.Regex == "" {
+		return g.re, nil
+	}
+	re, err := regexp.Compile(g.Regex)
+	if err != nil {
+		return nil, err
+	}
+	g.re = re
+	return re, nil
+}
+
+// Match reports whether filePath or the content of file belongs to this
+// group. Regex is matched against filePath exactly as given: Analyzer.Process
+// and the gosec/analysis adapter pass the file name recorded in the
+// token.FileSet, which is not made relative to the module root.
+func (g *Group) Match(filePath string, file *ast.File) bool {
+	if g.generated {
+		return isGeneratedFile(file)
+	}
+	re, err := g.compile()
+	if err != nil {
+		return false
+	}
+	if re == nil {
+		return g.Default
+	}
+	return re.MatchString(filePath)
+}
+
+// isGeneratedFile reports whether file's first non-blank line contains the
+// "DO NOT EDIT" marker convention used by protoc, stringer and friends.
+func isGeneratedFile(file *ast.File) bool {
+	if file == nil || len(file.Comments) == 0 {
+		return false
+	}
+	leading := file.Comments[0]
+	if leading.Pos() > file.Package {
+		return false
+	}
+	for _, comment := range leading.List {
+		line := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+		if line == "" {
+			continue
+		}
+		return doNotEditPattern.MatchString(line)
+	}
+	return false
+}
+
+// DefaultGroups are appended after any user-declared groups, so generated
+// code, vendored dependencies and test fixtures are classified out of the
+// box even when the config carries no Groups section of its own.
+func DefaultGroups() []Group {
+	return []Group{
+		{Name: "generated", generated: true},
+		{Name: "vendor", Regex: `(^|/)vendor/`},
+		{Name: "testdata", Regex: `(^|/)testdata/`},
+		{Name: "internal", Regex: `(^|/)internal/`},
+	}
+}
+
+// GroupFor returns the name of the first group (in declared order) whose
+// Match reports true for filePath/file, or "" if none match.
+func GroupFor(groups []Group, filePath string, file *ast.File) string {
+	for i := range groups {
+		if groups[i].Match(filePath, file) {
+			return groups[i].Name
+		}
+	}
+	return ""
+}
+
+// GroupRuleDisabled reports whether ruleID is explicitly disabled for group
+// via a per-group override in the rule's config section, e.g.:
+//
+//	G104: {generated: false, internal: true}
+//
+// A rule with no override, or a group of "", is never disabled by this
+// check.
+//
+// This is exported so the go/analysis adapter in gosec/analysis can apply
+// the same per-group disabling as Job.Visit does for a standalone
+// gosec.Analyzer.Process run.
+func GroupRuleDisabled(conf Config, ruleID, group string) bool {
+	if group == "" {
+		return false
+	}
+	settings, err := conf.Get(ruleID)
+	if err != nil {
+		return false
+	}
+	byGroup, ok := settings.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	enabled, ok := byGroup[group].(bool)
+	if !ok {
+		return false
+	}
+	return !enabled
+}
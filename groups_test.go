@@ -0,0 +1,136 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func parseTestFile(t *testing.T, src string) *ast.File {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	return file
+}
+
+func TestGroupMatchRegex(t *testing.T) {
+	group := Group{Name: "vendor", Regex: `(^|/)vendor/`}
+	file := parseTestFile(t, "package p\n")
+
+	if !group.Match("vendor/github.com/foo/bar.go", file) {
+		t.Error("expected vendor group to match a vendor/ path")
+	}
+	if group.Match("internal/bar.go", file) {
+		t.Error("expected vendor group not to match a non-vendor path")
+	}
+}
+
+func TestGroupMatchDefaultWithNoRegex(t *testing.T) {
+	file := parseTestFile(t, "package p\n")
+
+	catchAll := Group{Name: "catch-all", Default: true}
+	if !catchAll.Match("anything.go", file) {
+		t.Error("a Default:true group with no regex should match everything")
+	}
+
+	noMatch := Group{Name: "none", Default: false}
+	if noMatch.Match("anything.go", file) {
+		t.Error("a Default:false group with no regex should match nothing")
+	}
+}
+
+func TestGroupMatchGeneratedIsBuiltinOnly(t *testing.T) {
+	generatedSrc := "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage p\n"
+	generatedFile := parseTestFile(t, generatedSrc)
+	plainFile := parseTestFile(t, "package p\n")
+
+	builtin := DefaultGroups()[0]
+	if builtin.Name != "generated" {
+		t.Fatalf("expected DefaultGroups()[0] to be the generated group, got %q", builtin.Name)
+	}
+	if !builtin.Match("anything.go", generatedFile) {
+		t.Error("built-in generated group should match a DO NOT EDIT file")
+	}
+	if builtin.Match("anything.go", plainFile) {
+		t.Error("built-in generated group should not match a plain file")
+	}
+
+	// A user-declared group that merely happens to be named "generated"
+	// but carries its own regex must use that regex, not the DO NOT EDIT
+	// heuristic - it was never constructed through DefaultGroups.
+	userDeclared := Group{Name: "generated", Regex: `(^|/)gen/`}
+	if userDeclared.Match("anything.go", generatedFile) {
+		t.Error("user-declared \"generated\" group with a regex must not fall back to the DO NOT EDIT heuristic")
+	}
+	if !userDeclared.Match("gen/foo.go", plainFile) {
+		t.Error("user-declared \"generated\" group should match its own regex")
+	}
+}
+
+func TestGroupFor(t *testing.T) {
+	file := parseTestFile(t, "package p\n")
+	groups := []Group{
+		{Name: "vendor", Regex: `(^|/)vendor/`},
+		{Name: "internal", Regex: `(^|/)internal/`},
+	}
+
+	if got := GroupFor(groups, "vendor/foo.go", file); got != "vendor" {
+		t.Errorf("GroupFor = %q, want %q", got, "vendor")
+	}
+	if got := GroupFor(groups, "internal/foo.go", file); got != "internal" {
+		t.Errorf("GroupFor = %q, want %q", got, "internal")
+	}
+	if got := GroupFor(groups, "cmd/foo.go", file); got != "" {
+		t.Errorf("GroupFor = %q, want no match", got)
+	}
+}
+
+func TestGroupForFirstMatchWins(t *testing.T) {
+	file := parseTestFile(t, "package p\n")
+	groups := []Group{
+		{Name: "catch-all", Default: true},
+		{Name: "vendor", Regex: `(^|/)vendor/`},
+	}
+
+	if got := GroupFor(groups, "vendor/foo.go", file); got != "catch-all" {
+		t.Errorf("GroupFor = %q, want the first declared group %q to win", got, "catch-all")
+	}
+}
+
+func TestGroupRuleDisabled(t *testing.T) {
+	conf := NewConfig()
+	conf.Set("G104", map[string]interface{}{"generated": false, "internal": true})
+
+	if GroupRuleDisabled(conf, "G104", "") {
+		t.Error("a rule must never be disabled for the empty group")
+	}
+	if !GroupRuleDisabled(conf, "G104", "generated") {
+		t.Error("G104 should be disabled for the generated group")
+	}
+	if GroupRuleDisabled(conf, "G104", "internal") {
+		t.Error("G104 should not be disabled for the internal group")
+	}
+	if GroupRuleDisabled(conf, "G104", "vendor") {
+		t.Error("a group with no explicit override should not disable the rule")
+	}
+	if GroupRuleDisabled(conf, "G999", "generated") {
+		t.Error("a rule with no config section at all should not be disabled")
+	}
+}
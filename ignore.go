@@ -0,0 +1,210 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import (
+	"go/ast"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fileIgnoreDirective matches a "//gosec:file-ignore" comment at the top of a
+// file, followed by a comma separated list of rule ID patterns. A directive
+// with no patterns does not match.
+var fileIgnoreDirective = regexp.MustCompile(`^gosec:file-ignore\s+(\S+)`)
+
+// Ignore declares a suppression that can be matched against a discovered
+// Issue. Implementations are consulted, in order, whenever the analyzer is
+// about to report an issue. A suppression that is declared but never matches
+// anything is reported back through Analyzer.Report so that stale #nosec-style
+// directives can be cleaned up.
+//
+// This mirrors staticcheck's Ignore/LineIgnore/FileIgnore design.
+type Ignore interface {
+	// Match reports whether the ignore applies to the given issue. If it
+	// does, the issue should be suppressed and Matched must start returning
+	// true.
+	Match(issue *Issue) bool
+	// Matched reports whether Match has ever returned true for this ignore.
+	Matched() bool
+}
+
+// idGlobsMatch reports whether ruleID matches any of the given glob patterns.
+// Patterns use path/filepath.Match semantics (e.g. "G4*" matches "G401").
+func idGlobsMatch(patterns []string, ruleID string) bool {
+	for _, pattern := range patterns {
+		if pattern == ruleID {
+			return true
+		}
+		if ok, err := filepath.Match(pattern, ruleID); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// issueLine returns the first line number referenced by an issue, which may
+// report a single line or a "start-end" range.
+func issueLine(issue *Issue) int {
+	line := issue.Line
+	if idx := strings.Index(line, "-"); idx >= 0 {
+		line = line[:idx]
+	}
+	n, err := strconv.Atoi(line)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// issueCol returns the column number referenced by an issue.
+func issueCol(issue *Issue) int {
+	n, err := strconv.Atoi(issue.Col)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// FileIgnore suppresses every issue raised for IDs in a single file,
+// regardless of line, and is populated from a "//gosec:file-ignore"
+// directive found at the top of an *ast.File.
+type FileIgnore struct {
+	File    string
+	IDs     []string
+	matched bool
+}
+
+// NewFileIgnore searches the comment groups that precede file's package
+// clause for a "//gosec:file-ignore G101,G4*" directive and uses the first
+// one found. It returns nil, false if the file carries no such directive.
+func NewFileIgnore(file *ast.File, filePath string) (*FileIgnore, bool) {
+	for _, group := range file.Comments {
+		// The directive must precede the package clause to apply to the
+		// whole file.
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+			matches := fileIgnoreDirective.FindStringSubmatch(text)
+			if matches == nil {
+				continue
+			}
+			return &FileIgnore{File: filePath, IDs: strings.Split(matches[1], ",")}, true
+		}
+	}
+	return nil, false
+}
+
+// Match implements Ignore.
+func (f *FileIgnore) Match(issue *Issue) bool {
+	if issue.File != f.File || !idGlobsMatch(f.IDs, issue.RuleID) {
+		return false
+	}
+	f.matched = true
+	return true
+}
+
+// Matched implements Ignore.
+func (f *FileIgnore) Matched() bool {
+	return f.matched
+}
+
+// LineIgnore suppresses issues raised for IDs within a line range of a
+// single file. Unlike FileIgnore, which comes from a source comment,
+// LineIgnore entries are declared out-of-band in the gosec config so that
+// suppressions can be audited and reviewed independently of the code they
+// cover.
+type LineIgnore struct {
+	File      string
+	StartLine int
+	EndLine   int
+	IDs       []string
+	matched   bool
+}
+
+// Match implements Ignore.
+func (l *LineIgnore) Match(issue *Issue) bool {
+	if issue.File != l.File {
+		return false
+	}
+	line := issueLine(issue)
+	if line < l.StartLine || line > l.EndLine {
+		return false
+	}
+	if !idGlobsMatch(l.IDs, issue.RuleID) {
+		return false
+	}
+	l.matched = true
+	return true
+}
+
+// Matched implements Ignore.
+func (l *LineIgnore) Matched() bool {
+	return l.matched
+}
+
+// LineIgnoreConfig is the config-file representation of a LineIgnore entry,
+// used to unmarshal "ignores" sections from the gosec configuration.
+type LineIgnoreConfig struct {
+	File      string   `json:"file"`
+	StartLine int      `json:"start_line"`
+	EndLine   int      `json:"end_line"`
+	IDs       []string `json:"ids"`
+}
+
+// NewLineIgnores builds LineIgnore suppressions from their config
+// representation.
+func NewLineIgnores(entries []LineIgnoreConfig) []Ignore {
+	ignores := make([]Ignore, 0, len(entries))
+	for _, entry := range entries {
+		ignores = append(ignores, &LineIgnore{
+			File:      entry.File,
+			StartLine: entry.StartLine,
+			EndLine:   entry.EndLine,
+			IDs:       entry.IDs,
+		})
+	}
+	return ignores
+}
+
+// unusedIgnoreIssue synthesizes a diagnostic Issue reporting that a declared
+// suppression never matched a real finding during the scan.
+func unusedIgnoreIssue(ignore Ignore) *Issue {
+	switch ig := ignore.(type) {
+	case *FileIgnore:
+		return &Issue{
+			RuleID:     "nosec",
+			What:       "Unused file-ignore directive: " + strings.Join(ig.IDs, ","),
+			File:       ig.File,
+			Severity:   Low,
+			Confidence: High,
+		}
+	case *LineIgnore:
+		return &Issue{
+			RuleID:     "nosec",
+			What:       "Unused ignore directive: " + strings.Join(ig.IDs, ","),
+			File:       ig.File,
+			Line:       strconv.Itoa(ig.StartLine),
+			Severity:   Low,
+			Confidence: High,
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import "testing"
+
+func TestIdGlobsMatch(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		id       string
+		want     bool
+	}{
+		{[]string{"G401"}, "G401", true},
+		{[]string{"G4*"}, "G401", true},
+		{[]string{"G4*"}, "G402", true},
+		{[]string{"G4*"}, "G101", false},
+		{[]string{"G101", "G203"}, "G203", true},
+		{nil, "G101", false},
+	}
+	for _, c := range cases {
+		if got := idGlobsMatch(c.patterns, c.id); got != c.want {
+			t.Errorf("idGlobsMatch(%v, %q) = %v, want %v", c.patterns, c.id, got, c.want)
+		}
+	}
+}
+
+func TestFileIgnoreMatch(t *testing.T) {
+	ignore := &FileIgnore{File: "a.go", IDs: []string{"G4*"}}
+
+	if ignore.Matched() {
+		t.Fatal("expected Matched() to be false before any match")
+	}
+
+	otherFile := &Issue{File: "b.go", RuleID: "G401"}
+	if ignore.Match(otherFile) {
+		t.Error("FileIgnore matched an issue in a different file")
+	}
+	if ignore.Matched() {
+		t.Error("Matched() flipped true on a non-matching issue")
+	}
+
+	wrongRule := &Issue{File: "a.go", RuleID: "G101"}
+	if ignore.Match(wrongRule) {
+		t.Error("FileIgnore matched a rule ID outside its glob")
+	}
+
+	hit := &Issue{File: "a.go", RuleID: "G401"}
+	if !ignore.Match(hit) {
+		t.Error("FileIgnore did not match a rule ID covered by its glob")
+	}
+	if !ignore.Matched() {
+		t.Error("Matched() should be true after a successful Match")
+	}
+}
+
+func TestLineIgnoreMatch(t *testing.T) {
+	ignore := &LineIgnore{File: "a.go", StartLine: 10, EndLine: 12, IDs: []string{"G104"}}
+
+	inRange := &Issue{File: "a.go", Line: "11", RuleID: "G104"}
+	if !ignore.Match(inRange) {
+		t.Error("LineIgnore did not match an issue inside its line range")
+	}
+
+	outOfRange := &Issue{File: "a.go", Line: "20", RuleID: "G104"}
+	if ignore.Match(outOfRange) {
+		t.Error("LineIgnore matched an issue outside its line range")
+	}
+
+	wrongFile := &Issue{File: "b.go", Line: "11", RuleID: "G104"}
+	if ignore.Match(wrongFile) {
+		t.Error("LineIgnore matched an issue in a different file")
+	}
+}
+
+func TestUnusedIgnoreIssue(t *testing.T) {
+	fileIgnore := &FileIgnore{File: "a.go", IDs: []string{"G401"}}
+	issue := unusedIgnoreIssue(fileIgnore)
+	if issue == nil || issue.File != "a.go" {
+		t.Fatalf("unusedIgnoreIssue(FileIgnore) = %+v, want an issue for a.go", issue)
+	}
+
+	lineIgnore := &LineIgnore{File: "b.go", StartLine: 5, IDs: []string{"G104"}}
+	issue = unusedIgnoreIssue(lineIgnore)
+	if issue == nil || issue.Line != "5" {
+		t.Fatalf("unusedIgnoreIssue(LineIgnore) = %+v, want line 5", issue)
+	}
+}
@@ -0,0 +1,159 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import (
+	"go/ast"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// nosecPattern pulls rule IDs out of an inline "#nosec" comment.
+var nosecPattern = regexp.MustCompile(`(G\d{3})`)
+
+// ParseNosecComment extracts the rule IDs excluded by an inline "#nosec"
+// comment attached to n, if any. isNosec reports whether n carried a
+// "#nosec" comment at all, so callers can keep an accurate NumNosec count
+// even when every rule is already ignored globally.
+//
+// This is exported so the go/analysis adapter in gosec/analysis can
+// preserve exactly the same #nosec handling as Job, rather than
+// reimplementing it.
+func ParseNosecComment(comments ast.CommentMap, n ast.Node) (ids []string, ignoreAll, isNosec bool) {
+	groups, ok := comments[n]
+	if !ok {
+		return nil, false, false
+	}
+	for _, group := range groups {
+		if !strings.Contains(group.Text(), "#nosec") {
+			continue
+		}
+		matches := nosecPattern.FindAllStringSubmatch(group.Text(), -1)
+		if len(matches) == 0 {
+			return nil, true, true
+		}
+		for _, m := range matches {
+			ids = append(ids, m[1])
+		}
+		return ids, false, true
+	}
+	return nil, false, false
+}
+
+// Job is the unit of concurrent work scheduled by Analyzer.Process: scanning
+// a single *ast.File. Each Job owns its own Context and #nosec ignores
+// stack, so many Jobs can be run in parallel without sharing mutable state -
+// the kind of state that used to live directly on Analyzer when scanning was
+// strictly serial.
+type Job struct {
+	analyzer *Analyzer
+	context  *Context
+	ignores  []map[string]bool
+	group    string // classifies this file's path, see GroupFor
+
+	issues []*Issue
+	stats  Metrics
+	errors map[string][]Error
+}
+
+// NewJob builds a Job that will scan the file described by ctx.
+func NewJob(analyzer *Analyzer, ctx *Context) *Job {
+	return &Job{
+		analyzer: analyzer,
+		context:  ctx,
+		issues:   make([]*Issue, 0, 4),
+		errors:   make(map[string][]Error),
+	}
+}
+
+// Run walks the job's file, invoking registered rules on every node, and
+// accumulates issues/stats/errors on the Job itself. The caller is
+// responsible for merging the results back into the owning Analyzer.
+func (job *Job) Run() {
+	ast.Walk(job, job.context.Root)
+	job.stats.NumFiles++
+	job.stats.NumLines += job.context.FileSet.File(job.context.Root.Pos()).LineCount()
+}
+
+// ignore a node (and sub-tree) if it is tagged with a "#nosec" comment.
+func (job *Job) ignore(n ast.Node) ([]string, bool) {
+	if job.analyzer.ignoreNosec {
+		return nil, false
+	}
+	ids, ignoreAll, isNosec := ParseNosecComment(job.context.Comments, n)
+	if isNosec {
+		job.stats.NumNosec++
+	}
+	return ids, ignoreAll
+}
+
+// Visit runs the gosec visitor logic over an AST created by parsing go code.
+// It is the per-Job equivalent of the Visit method Analyzer used to carry
+// when scanning was single-threaded.
+func (job *Job) Visit(n ast.Node) ast.Visitor {
+	// If we've reached the end of this branch, pop off the ignores stack.
+	if n == nil {
+		if len(job.ignores) > 0 {
+			job.ignores = job.ignores[1:]
+		}
+		return job
+	}
+
+	// Get any new rule exclusions.
+	ignoredRules, ignoreAll := job.ignore(n)
+	if ignoreAll {
+		return nil
+	}
+
+	// Now create the union of exclusions.
+	ignores := make(map[string]bool, 0)
+	if len(job.ignores) > 0 {
+		for k, v := range job.ignores[0] {
+			ignores[k] = v
+		}
+	}
+
+	for _, v := range ignoredRules {
+		ignores[v] = true
+	}
+
+	// Push the new set onto the stack.
+	job.ignores = append([]map[string]bool{ignores}, job.ignores...)
+
+	// Track aliased and initialization imports
+	job.context.Imports.TrackImport(n)
+
+	for _, rule := range job.analyzer.ruleset.RegisteredFor(n) {
+		if _, ok := ignores[rule.ID()]; ok {
+			continue
+		}
+		if GroupRuleDisabled(job.context.Config, rule.ID(), job.group) {
+			continue
+		}
+		issue, err := rule.Match(n, job.context)
+		if err != nil {
+			file, line := GetLocation(n, job.context)
+			file = path.Base(file)
+			job.analyzer.logger.Printf("Rule error: %v => %s (%s:%d)\n", reflect.TypeOf(rule), err, file, line)
+		}
+		if issue != nil && !job.analyzer.suppressed(issue) {
+			job.issues = append(job.issues, issue)
+			job.stats.NumFound++
+		}
+	}
+	return job
+}
@@ -0,0 +1,130 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// BuildSSA gates the (relatively expensive) construction of an SSA
+// representation of the scanned packages. It defaults to off; set it in the
+// gosec config for rules that implement SSARule to run.
+const BuildSSA GlobalOption = "build_ssa"
+
+// SSARule is implemented by rules that want to reason about a function's
+// SSA form in addition to, or instead of, pure AST pattern matching. This
+// suits interprocedural questions - taint tracking for injection rules,
+// constant propagation for hardcoded credentials, reachability for dead
+// crypto - that are awkward to answer from the AST alone.
+//
+// MatchSSA is called once per *ssa.Function discovered in the packages
+// being scanned, alongside the regular AST walk used for Rule.Match.
+type SSARule interface {
+	MatchSSA(fn *ssa.Function, ctx *Context) (*Issue, error)
+}
+
+// buildSSA constructs the *ssa.Program for pkgs and returns the resulting
+// per-package *ssa.Package, keyed by the types.Package it was built from so
+// that it can be attached to the matching Job's Context.
+func buildSSA(pkgs []*packages.Package) (*ssa.Program, map[*types.Package]*ssa.Package) {
+	prog, ssaPkgs := ssautil.Packages(pkgs, ssa.GlobalDebug)
+	prog.Build()
+
+	byTypes := make(map[*types.Package]*ssa.Package, len(ssaPkgs))
+	for i, ssaPkg := range ssaPkgs {
+		if ssaPkg == nil || pkgs[i].Types == nil {
+			continue
+		}
+		byTypes[pkgs[i].Types] = ssaPkg
+	}
+	return prog, byTypes
+}
+
+// runSSARules runs every registered SSARule once per function across all
+// scanned packages that have an SSA representation. Unlike the per-file Job
+// pool, this walks packages rather than files, since an *ssa.Package is
+// built once per package regardless of how many files it spans.
+func (gosec *Analyzer) runSSARules(pkgs []*packages.Package, prog *ssa.Program, byTypes map[*types.Package]*ssa.Package) {
+	if len(gosec.ssaRules) == 0 {
+		return
+	}
+
+	for _, pkg := range pkgs {
+		ssaPkg, ok := byTypes[pkg.Types]
+		if !ok {
+			continue
+		}
+		ctx := &Context{
+			FileSet: pkg.Fset,
+			Config:  gosec.config,
+			Info:    pkg.TypesInfo,
+			Pkg:     pkg.Types,
+			SSAProg: prog,
+			SSAPkg:  ssaPkg,
+		}
+		for _, fn := range ssaFunctions(ssaPkg) {
+			for _, rule := range gosec.ssaRules {
+				issue, err := rule.MatchSSA(fn, ctx)
+				if err != nil {
+					gosec.logger.Printf("SSA rule error: %v => %s\n", rule, err)
+					continue
+				}
+				if issue != nil && !gosec.suppressed(issue) {
+					gosec.issues = append(gosec.issues, issue)
+					gosec.stats.NumFound++
+				}
+			}
+		}
+	}
+}
+
+// ssaFunctions returns every function defined in pkg - including methods
+// and nested closures - for SSARule.MatchSSA to be run over.
+func ssaFunctions(pkg *ssa.Package) []*ssa.Function {
+	if pkg == nil {
+		return nil
+	}
+
+	var fns []*ssa.Function
+	var walk func(fn *ssa.Function)
+	walk = func(fn *ssa.Function) {
+		fns = append(fns, fn)
+		for _, anon := range fn.AnonFuncs {
+			walk(anon)
+		}
+	}
+
+	for _, member := range pkg.Members {
+		switch m := member.(type) {
+		case *ssa.Function:
+			walk(m)
+		case *ssa.Type:
+			named, ok := m.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				if fn := pkg.Prog.FuncValue(named.Method(i)); fn != nil {
+					walk(fn)
+				}
+			}
+		}
+	}
+	return fns
+}
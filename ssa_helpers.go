@@ -0,0 +1,111 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// CallSitesOf returns every ssa.CallInstruction in prog whose static callee
+// is target. Dynamic calls, such as interface method calls, have no static
+// callee and are not returned. Rule authors use this to find the callers of
+// a sensitive function (e.g. os/exec.Command) without walking the AST
+// themselves.
+func CallSitesOf(prog *ssa.Program, target *types.Func) []ssa.CallInstruction {
+	var sites []ssa.CallInstruction
+	for fn := range ssautil.AllFunctions(prog) {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				callee := call.Common().StaticCallee()
+				if callee == nil || callee.Object() == nil {
+					continue
+				}
+				if fnObj, ok := callee.Object().(*types.Func); ok && fnObj == target {
+					sites = append(sites, call)
+				}
+			}
+		}
+	}
+	return sites
+}
+
+// DerivesFrom walks the def-use chain backwards from v, reporting whether
+// v's value can be traced to a static call of a function or method in
+// sourcePkg named sourceName (e.g. "os", "Getenv", or "net/http",
+// "FormValue" for Request.FormValue), or to a package-level variable in
+// sourcePkg of that name (e.g. "os", "Args"). It follows *ssa.Phi,
+// unary/binary ops, conversions and field/index address selections. Each
+// value is visited at most once, so cyclic phi nodes cannot loop forever.
+func DerivesFrom(v ssa.Value, sourcePkg, sourceName string) bool {
+	return derivesFrom(v, sourcePkg, sourceName, make(map[ssa.Value]bool))
+}
+
+func derivesFrom(v ssa.Value, sourcePkg, sourceName string, seen map[ssa.Value]bool) bool {
+	if v == nil || seen[v] {
+		return false
+	}
+	seen[v] = true
+
+	switch val := v.(type) {
+	case *ssa.Global:
+		if val.Pkg != nil && val.Pkg.Pkg != nil && val.Pkg.Pkg.Path() == sourcePkg && val.Name() == sourceName {
+			return true
+		}
+		return false
+	case *ssa.Call:
+		callee := val.Common().StaticCallee()
+		if callee == nil {
+			return false
+		}
+		fn, ok := callee.Object().(*types.Func)
+		if !ok {
+			return false
+		}
+		if fn.Pkg() != nil && fn.Pkg().Path() == sourcePkg && fn.Name() == sourceName {
+			return true
+		}
+		return false
+	case *ssa.Phi:
+		for _, edge := range val.Edges {
+			if derivesFrom(edge, sourcePkg, sourceName, seen) {
+				return true
+			}
+		}
+		return false
+	case *ssa.Convert:
+		return derivesFrom(val.X, sourcePkg, sourceName, seen)
+	case *ssa.ChangeType:
+		return derivesFrom(val.X, sourcePkg, sourceName, seen)
+	case *ssa.MakeInterface:
+		return derivesFrom(val.X, sourcePkg, sourceName, seen)
+	case *ssa.UnOp:
+		return derivesFrom(val.X, sourcePkg, sourceName, seen)
+	case *ssa.BinOp:
+		return derivesFrom(val.X, sourcePkg, sourceName, seen) || derivesFrom(val.Y, sourcePkg, sourceName, seen)
+	case *ssa.FieldAddr:
+		return derivesFrom(val.X, sourcePkg, sourceName, seen)
+	case *ssa.IndexAddr:
+		return derivesFrom(val.X, sourcePkg, sourceName, seen)
+	default:
+		return false
+	}
+}
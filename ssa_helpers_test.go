@@ -0,0 +1,162 @@
+// (c) Copyright 2016 Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gosec
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+const ssaHelperTestSrc = `
+package p
+
+import "os"
+
+func tainted() string {
+	return os.Getenv("X")
+}
+
+func untainted() string {
+	return "safe"
+}
+
+func fromArgs() string {
+	return os.Args[0]
+}
+`
+
+// buildTestSSA type-checks and builds SSA for ssaHelperTestSrc, returning the
+// resulting package alongside the *types.Package for "os" so tests can look
+// up well-known functions such as os.Getenv.
+func buildTestSSA(t *testing.T) (*ssa.Package, *types.Package) {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", ssaHelperTestSrc, 0)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	imp := importer.Default()
+	conf := types.Config{Importer: imp}
+	pkg, err := conf.Check("p", fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatalf("type-check error: %v", err)
+	}
+
+	var osPkg *types.Package
+	for _, imported := range pkg.Imports() {
+		if imported.Path() == "os" {
+			osPkg = imported
+		}
+	}
+	if osPkg == nil {
+		t.Fatal("\"p\" did not import \"os\" as expected")
+	}
+
+	prog := ssa.NewProgram(fset, ssa.SanityCheckFunctions)
+	prog.CreatePackage(osPkg, nil, nil, true)
+	ssaPkg := prog.CreatePackage(pkg, []*ast.File{file}, info, false)
+	prog.Build()
+
+	return ssaPkg, osPkg
+}
+
+// returnOperand finds fn's first return statement and reports the SSA value
+// it returns, for tests that only care about the tainted-ness of a single
+// result.
+func returnOperand(fn *ssa.Function) ssa.Value {
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			if ret, ok := instr.(*ssa.Return); ok && len(ret.Results) > 0 {
+				return ret.Results[0]
+			}
+		}
+	}
+	return nil
+}
+
+func TestDerivesFromCall(t *testing.T) {
+	ssaPkg, _ := buildTestSSA(t)
+
+	tainted := ssaPkg.Func("tainted")
+	if tainted == nil {
+		t.Fatal("function \"tainted\" not found in built SSA package")
+	}
+	if v := returnOperand(tainted); !DerivesFrom(v, "os", "Getenv") {
+		t.Error("expected tainted()'s return value to derive from os.Getenv")
+	}
+
+	untainted := ssaPkg.Func("untainted")
+	if untainted == nil {
+		t.Fatal("function \"untainted\" not found in built SSA package")
+	}
+	if v := returnOperand(untainted); DerivesFrom(v, "os", "Getenv") {
+		t.Error("untainted()'s return value must not derive from os.Getenv")
+	}
+}
+
+func TestDerivesFromGlobal(t *testing.T) {
+	ssaPkg, _ := buildTestSSA(t)
+
+	fromArgs := ssaPkg.Func("fromArgs")
+	if fromArgs == nil {
+		t.Fatal("function \"fromArgs\" not found in built SSA package")
+	}
+	if v := returnOperand(fromArgs); !DerivesFrom(v, "os", "Args") {
+		t.Error("expected fromArgs()'s return value to derive from os.Args")
+	}
+}
+
+func TestDerivesFromNilValue(t *testing.T) {
+	if DerivesFrom(nil, "os", "Getenv") {
+		t.Error("DerivesFrom(nil, ...) must be false")
+	}
+}
+
+func TestCallSitesOf(t *testing.T) {
+	ssaPkg, osPkg := buildTestSSA(t)
+
+	getenv, ok := osPkg.Scope().Lookup("Getenv").(*types.Func)
+	if !ok {
+		t.Fatal("os.Getenv not found")
+	}
+
+	sites := CallSitesOf(ssaPkg.Prog, getenv)
+	if len(sites) != 1 {
+		t.Fatalf("got %d call sites for os.Getenv, want 1", len(sites))
+	}
+
+	untainted := ssaPkg.Func("untainted")
+	for _, site := range sites {
+		if site.Parent() == untainted {
+			t.Error("os.Getenv call site wrongly attributed to untainted()")
+		}
+	}
+}